@@ -0,0 +1,38 @@
+// Package auth provides simple HTTP middleware for gating scrape endpoints,
+// so a /metrics handler can require a bearer token or basic auth before a
+// multi-tenant deployment hands out scrape credentials.
+package auth
+
+import (
+    "crypto/subtle"
+    "net/http"
+)
+
+// BearerToken requires an "Authorization: Bearer <token>" header matching
+// token, responding 401 otherwise.
+func BearerToken(token string, h http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+            w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+            http.Error(w, "unauthorized", http.StatusUnauthorized)
+            return
+        }
+        h.ServeHTTP(w, r)
+    })
+}
+
+// BasicAuth requires HTTP basic auth matching user/pass, responding 401
+// otherwise.
+func BasicAuth(user, pass string, h http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        u, p, ok := r.BasicAuth()
+        if !ok ||
+            subtle.ConstantTimeCompare([]byte(u), []byte(user)) != 1 ||
+            subtle.ConstantTimeCompare([]byte(p), []byte(pass)) != 1 {
+            w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+            http.Error(w, "unauthorized", http.StatusUnauthorized)
+            return
+        }
+        h.ServeHTTP(w, r)
+    })
+}
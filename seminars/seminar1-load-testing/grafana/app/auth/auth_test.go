@@ -0,0 +1,75 @@
+package auth
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func okHandler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    })
+}
+
+func TestBearerToken(t *testing.T) {
+    h := BearerToken("secret", okHandler())
+
+    cases := []struct {
+        name   string
+        header string
+        want   int
+    }{
+        {"matching token", "Bearer secret", http.StatusOK},
+        {"wrong token", "Bearer wrong", http.StatusUnauthorized},
+        {"missing header", "", http.StatusUnauthorized},
+    }
+
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            if tc.header != "" {
+                req.Header.Set("Authorization", tc.header)
+            }
+            rec := httptest.NewRecorder()
+
+            h.ServeHTTP(rec, req)
+
+            if rec.Code != tc.want {
+                t.Errorf("status = %d, want %d", rec.Code, tc.want)
+            }
+        })
+    }
+}
+
+func TestBasicAuth(t *testing.T) {
+    h := BasicAuth("user", "pass", okHandler())
+
+    cases := []struct {
+        name       string
+        user, pass string
+        setAuth    bool
+        want       int
+    }{
+        {"matching credentials", "user", "pass", true, http.StatusOK},
+        {"wrong password", "user", "wrong", true, http.StatusUnauthorized},
+        {"wrong user", "other", "pass", true, http.StatusUnauthorized},
+        {"no credentials", "", "", false, http.StatusUnauthorized},
+    }
+
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            if tc.setAuth {
+                req.SetBasicAuth(tc.user, tc.pass)
+            }
+            rec := httptest.NewRecorder()
+
+            h.ServeHTTP(rec, req)
+
+            if rec.Code != tc.want {
+                t.Errorf("status = %d, want %d", rec.Code, tc.want)
+            }
+        })
+    }
+}
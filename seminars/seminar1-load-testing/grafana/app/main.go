@@ -1,36 +1,231 @@
 package main
 
 import (
+    "context"
+    "crypto/tls"
+    "flag"
     "fmt"
+    "log"
     "net/http"
+    "os/signal"
+    "strings"
+    "syscall"
+    "time"
 
     "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
     "github.com/prometheus/client_golang/prometheus/promhttp"
-)
+    "github.com/prometheus/common/version"
 
-// Example counter
-var requestsTotal = prometheus.NewCounterVec(
-    prometheus.CounterOpts{
-        Name: "app_requests_total",
-        Help: "Total HTTP requests",
-    },
-    []string{"path"},
+    "github.com/Fr0stFree/ITMO-DDIA-HW/seminars/seminar1-load-testing/grafana/app/auth"
+    "github.com/Fr0stFree/ITMO-DDIA-HW/seminars/seminar1-load-testing/grafana/app/metrics"
+    "github.com/Fr0stFree/ITMO-DDIA-HW/seminars/seminar1-load-testing/grafana/app/pusher"
 )
 
-func init() {
-    prometheus.MustRegister(requestsTotal)
+// program is the name used for the app_build_info gauge. The gauge's labels
+// come from github.com/prometheus/common/version, set at build time via
+// ldflags, e.g.:
+//
+//	go build -ldflags "-X github.com/prometheus/common/version.Version=1.2.3 \
+//	  -X github.com/prometheus/common/version.Revision=$(git rev-parse HEAD) \
+//	  -X github.com/prometheus/common/version.Branch=$(git branch --show-current) \
+//	  -X github.com/prometheus/common/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+const program = "app"
+
+// buildInfoGaugeFunc reports a constant '1' gauge named "<program>_build_info",
+// labeled with version, revision, branch, goversion and build_date, all
+// populated via -ldflags -X on github.com/prometheus/common/version (see
+// program's doc comment). It's hand-rolled rather than
+// prometheus/collectors/version.NewCollector because that collector doesn't
+// expose build_date.
+func buildInfoGaugeFunc(program string) prometheus.Collector {
+    return prometheus.NewGaugeFunc(
+        prometheus.GaugeOpts{
+            Name: program + "_build_info",
+            Help: fmt.Sprintf(
+                "A metric with a constant '1' value labeled by version, revision, branch, goversion and build_date from which %s was built.",
+                program,
+            ),
+            ConstLabels: prometheus.Labels{
+                "version":    version.Version,
+                "revision":   version.Revision,
+                "branch":     version.Branch,
+                "goversion":  version.GoVersion,
+                "build_date": version.BuildDate,
+            },
+        },
+        func() float64 { return 1 },
+    )
 }
 
+var (
+    listenAddress = flag.String("listen-address", ":8081", "address to serve /metrics/* on")
+    tenantsFlag   = flag.String("tenants", "", "comma-separated tenant IDs, each exposed at /metrics/tenant/{id} on its own registry and served at /tenant/{id}/")
+
+    tlsCertFile = flag.String("tls-cert-file", "", "TLS certificate file; when set with -tls-key-file, serves over HTTPS")
+    tlsKeyFile  = flag.String("tls-key-file", "", "TLS private key file")
+
+    scrapeBearerToken = flag.String("scrape-bearer-token", "", "if set, require this bearer token to scrape any /metrics/* endpoint")
+    scrapeBasicUser   = flag.String("scrape-basic-user", "", "if set with -scrape-basic-pass, require this basic auth username to scrape any /metrics/* endpoint")
+    scrapeBasicPass   = flag.String("scrape-basic-pass", "", "basic auth password, paired with -scrape-basic-user")
+
+    metricsMaxInFlight        = flag.Int("metrics-max-in-flight", 0, "max concurrent requests served per /metrics/* endpoint; zero means unlimited")
+    metricsTimeout            = flag.Duration("metrics-timeout", 10*time.Second, "max time to serve a single /metrics/* request")
+    metricsDisableCompression = flag.Bool("metrics-disable-compression", false, "disable gzip compression of /metrics/* responses")
+
+    pushGatewayURL = flag.String("push-gateway", "", "Pushgateway URL (e.g. http://pushgateway:9091); when set, the binary pushes its metrics instead of serving /metrics/*")
+    pushJob        = flag.String("push-job", program, "\"job\" grouping label used when pushing to the Pushgateway")
+    pushInstance   = flag.String("push-instance", "", "\"instance\" grouping label used when pushing to the Pushgateway")
+    pushInterval   = flag.Duration("push-interval", 0, "interval between pushes; zero pushes once on exit")
+    pushReplace    = flag.Bool("push-replace", false, "use Pushgateway Push (replace) semantics instead of Add")
+    pushUser       = flag.String("push-user", "", "basic auth username for the Pushgateway")
+    pushPass       = flag.String("push-pass", "", "basic auth password for the Pushgateway")
+)
+
 func handler(w http.ResponseWriter, r *http.Request) {
-    requestsTotal.WithLabelValues(r.URL.Path).Inc()
     fmt.Fprintln(w, "OK")
 }
 
 func main() {
-    http.HandleFunc("/", handler)
+    flag.Parse()
+
+    appReg := prometheus.NewRegistry()
+    metrics.Register(appReg)
+
+    runtimeReg := prometheus.NewRegistry()
+    metrics.RegisterRuntime(runtimeReg)
+    runtimeReg.MustRegister(
+        buildInfoGaugeFunc(program),
+        collectors.NewBuildInfoCollector(),
+    )
+
+    tenantList, err := buildTenants(*tenantsFlag)
+    if err != nil {
+        log.Fatalf("invalid -tenants: %v", err)
+    }
+
+    if *pushGatewayURL != "" {
+        gatherers := prometheus.Gatherers{appReg, runtimeReg}
+        for _, t := range tenantList {
+            gatherers = append(gatherers, t.reg)
+        }
+        runPushJob(gatherers)
+        return
+    }
+
+    http.Handle("/", metrics.Wrap("/", http.HandlerFunc(handler)))
+    http.Handle("/metrics/app", scrapeHandler(appReg))
+    http.Handle("/metrics/runtime", scrapeHandler(runtimeReg))
+    for _, t := range tenantList {
+        http.Handle("/metrics/tenant/"+t.id, scrapeHandler(t.reg))
+        http.Handle("/tenant/"+t.id+"/", http.StripPrefix("/tenant/"+t.id, t.metrics.Wrap(t.id, http.HandlerFunc(handler))))
+    }
+
+    serve()
+}
+
+// tenant bundles one tenant's isolated registry with the Metrics set
+// recording its own traffic, so its scrape endpoint reports that tenant's
+// RED series rather than duplicating process-wide collectors.
+type tenant struct {
+    id      string
+    reg     *prometheus.Registry
+    metrics *metrics.Metrics
+}
+
+// buildTenants builds one tenant per comma-separated ID in csv, each with
+// its own Registry and its own tenant-labeled Metrics set, so a tenant's
+// traffic (served at /tenant/{id}/) and scrape endpoint
+// (/metrics/tenant/{id}) never share series with another tenant's. It
+// returns an error for a duplicate ID rather than building two tenants that
+// would register the same HTTP pattern twice and panic at startup.
+func buildTenants(csv string) ([]tenant, error) {
+    seen := make(map[string]struct{})
+    var tenants []tenant
+    for _, id := range strings.Split(csv, ",") {
+        id = strings.TrimSpace(id)
+        if id == "" {
+            continue
+        }
+        if _, dup := seen[id]; dup {
+            return nil, fmt.Errorf("duplicate tenant id %q", id)
+        }
+        seen[id] = struct{}{}
+
+        reg := prometheus.NewRegistry()
+        tm := metrics.NewTenant(id)
+        tm.Register(reg)
+        tenants = append(tenants, tenant{id: id, reg: reg, metrics: tm})
+    }
+    return tenants, nil
+}
+
+// scrapeHandler builds the promhttp handler for reg, tuned per the
+// -metrics-* flags, and wraps it in the auth middleware selected by the
+// -scrape-* flags, if any: a bearer token when -scrape-bearer-token is set,
+// otherwise basic auth when -scrape-basic-user is set. Only one of the two is
+// applied; a set bearer token takes precedence over basic auth.
+func scrapeHandler(reg prometheus.Gatherer) http.Handler {
+    h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{
+        ErrorHandling: promhttp.ContinueOnError,
+        // EnableOpenMetrics lets promhttp negotiate the OpenMetrics
+        // content-type (needed to serve exemplars) when the scraper sends
+        // Accept: application/openmetrics-text.
+        EnableOpenMetrics:   true,
+        MaxRequestsInFlight: *metricsMaxInFlight,
+        Timeout:             *metricsTimeout,
+        DisableCompression:  *metricsDisableCompression,
+    })
+
+    switch {
+    case *scrapeBearerToken != "":
+        return auth.BearerToken(*scrapeBearerToken, h)
+    case *scrapeBasicUser != "":
+        return auth.BasicAuth(*scrapeBasicUser, *scrapeBasicPass, h)
+    default:
+        return h
+    }
+}
+
+// serve starts the HTTP(S) server, using TLS with certificate hot-reload
+// when -tls-cert-file/-tls-key-file are set.
+func serve() {
+    if *tlsCertFile == "" {
+        fmt.Println("Server listening on", *listenAddress)
+        http.ListenAndServe(*listenAddress, nil)
+        return
+    }
+
+    reloader := newCertReloader(*tlsCertFile, *tlsKeyFile, time.Minute)
+    server := &http.Server{
+        Addr: *listenAddress,
+        TLSConfig: &tls.Config{
+            GetCertificate: reloader.GetCertificate,
+        },
+    }
+
+    fmt.Println("Server listening on", *listenAddress, "(TLS)")
+    server.ListenAndServeTLS("", "")
+}
+
+// runPushJob runs as a short-lived batch job, pushing gatherers' collectors
+// to a Pushgateway instead of serving /metrics/*, and flushing a final push
+// on SIGTERM/SIGINT so nothing is lost when the scheduler kills the job.
+func runPushJob(gatherers prometheus.Gatherer) {
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+    defer stop()
 
-    http.Handle("/metrics", promhttp.Handler())
+    p := pusher.New(pusher.Config{
+        URL:           *pushGatewayURL,
+        Job:           *pushJob,
+        Instance:      *pushInstance,
+        BasicAuthUser: *pushUser,
+        BasicAuthPass: *pushPass,
+        Interval:      *pushInterval,
+        Replace:       *pushReplace,
+    }, gatherers)
 
-    fmt.Println("Server listening on :8081")
-    http.ListenAndServe(":8081", nil)
+    if err := p.Run(ctx); err != nil {
+        fmt.Println("push to Pushgateway failed:", err)
+    }
 }
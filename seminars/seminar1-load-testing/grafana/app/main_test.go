@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestBuildTenantsParsesTrimsAndSkipsEmpty(t *testing.T) {
+    tenants, err := buildTenants(" acme , globex ,,")
+    if err != nil {
+        t.Fatalf("buildTenants: %v", err)
+    }
+    if len(tenants) != 2 {
+        t.Fatalf("len(tenants) = %d, want 2", len(tenants))
+    }
+    if tenants[0].id != "acme" || tenants[1].id != "globex" {
+        t.Fatalf("tenants = %+v, want ids [acme globex]", tenants)
+    }
+}
+
+func TestBuildTenantsRejectsDuplicates(t *testing.T) {
+    if _, err := buildTenants("acme,acme"); err == nil {
+        t.Fatal("buildTenants: want error for duplicate tenant id, got nil")
+    }
+}
+
+func TestBuildTenantsEmptyInputYieldsNoTenants(t *testing.T) {
+    tenants, err := buildTenants("")
+    if err != nil {
+        t.Fatalf("buildTenants: %v", err)
+    }
+    if len(tenants) != 0 {
+        t.Fatalf("len(tenants) = %d, want 0", len(tenants))
+    }
+}
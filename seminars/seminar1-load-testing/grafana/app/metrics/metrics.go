@@ -0,0 +1,209 @@
+// Package metrics provides a small RED-method (Rate, Errors, Duration)
+// instrumentation layer for net/http handlers, modeled after the
+// HTTPMiddleware used in ipld-eth-server: wrap a handler once and every
+// request through it gets counted, timed and sized automatically.
+package metrics
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics is a self-contained set of RED-method collectors: every field is
+// its own CounterVec/HistogramVec/SummaryVec/Gauge instance, so two Metrics
+// values never share series and can be registered on different registries
+// (or merged via prometheus.Gatherers, e.g. for a Pushgateway push) without
+// colliding.
+type Metrics struct {
+    requestsTotal   *prometheus.CounterVec
+    requestDuration *prometheus.HistogramVec
+    requestSize     *prometheus.SummaryVec
+    responseSize    *prometheus.SummaryVec
+    inFlightGauge   prometheus.Gauge
+}
+
+// New builds a Metrics set. constLabels is attached to every series and
+// exists so multiple Metrics sets can be merged into one Gatherer (e.g. for
+// a multi-tenant push) without their identically-named families colliding;
+// pass nil for a set that isn't partitioned that way.
+func New(constLabels prometheus.Labels) *Metrics {
+    return &Metrics{
+        requestsTotal: prometheus.NewCounterVec(
+            prometheus.CounterOpts{
+                Name:        "app_requests_total",
+                Help:        "Total HTTP requests processed, partitioned by method, path and status.",
+                ConstLabels: constLabels,
+            },
+            []string{"method", "path", "status"},
+        ),
+        requestDuration: prometheus.NewHistogramVec(
+            prometheus.HistogramOpts{
+                Name:        "http_request_duration_seconds",
+                Help:        "Latency of HTTP requests in seconds, partitioned by method, path and status.",
+                Buckets:     prometheus.DefBuckets,
+                ConstLabels: constLabels,
+            },
+            []string{"method", "path", "status"},
+        ),
+        requestSize: prometheus.NewSummaryVec(
+            prometheus.SummaryOpts{
+                Name:        "http_request_size_bytes",
+                Help:        "Size of HTTP request bodies in bytes, partitioned by method and path.",
+                Objectives:  map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+                ConstLabels: constLabels,
+            },
+            []string{"method", "path"},
+        ),
+        responseSize: prometheus.NewSummaryVec(
+            prometheus.SummaryOpts{
+                Name:        "http_response_size_bytes",
+                Help:        "Size of HTTP response bodies in bytes, partitioned by method and path.",
+                Objectives:  map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+                ConstLabels: constLabels,
+            },
+            []string{"method", "path"},
+        ),
+        inFlightGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name:        "http_requests_in_flight",
+            Help:        "Current number of HTTP requests being served.",
+            ConstLabels: constLabels,
+        }),
+    }
+}
+
+// NewTenant builds a Metrics set scoped to tenant: every series carries a
+// "tenant" label set to tenant, so a tenant's registry reports genuinely
+// tenant-specific request rate/errors/duration rather than duplicating
+// process-wide collectors under a new path.
+func NewTenant(tenant string) *Metrics {
+    return New(prometheus.Labels{"tenant": tenant})
+}
+
+var defaultMetrics = New(nil)
+
+// Register adds m's HTTP request metrics (rate, errors, duration, sizes,
+// in-flight) to reg. Callers own the Registerer (the default registry, or a
+// dedicated one created with prometheus.NewRegistry) so multiple registries
+// can each expose their own Metrics set without cross-registering.
+func (m *Metrics) Register(reg prometheus.Registerer) {
+    reg.MustRegister(
+        m.requestsTotal,
+        m.requestDuration,
+        m.requestSize,
+        m.responseSize,
+        m.inFlightGauge,
+    )
+}
+
+// Register adds the package-level default Metrics set (the app's own
+// request metrics) to reg. See RegisterRuntime for Go/process metrics,
+// which are commonly exposed on a separate registry/endpoint, and NewTenant
+// for per-tenant sets.
+func Register(reg prometheus.Registerer) {
+    defaultMetrics.Register(reg)
+}
+
+// RegisterRuntime adds the standard Go and process collectors to reg. It's
+// kept separate from Register so a deployment can expose runtime metrics
+// under their own low-cardinality, rarely-auth-gated endpoint. Unlike
+// Metrics, it isn't tenant-scoped: registering it on more than one registry
+// that later gets merged (e.g. for a Pushgateway push) produces duplicate
+// series, so call it at most once per process.
+func RegisterRuntime(reg prometheus.Registerer) {
+    reg.MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+    )
+}
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code and
+// byte count written by the downstream handler, since net/http doesn't
+// surface either after the fact.
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+    size   int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+    r.status = status
+    r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+    n, err := r.ResponseWriter.Write(b)
+    r.size += n
+    return n, err
+}
+
+// Wrap instruments h with m's request-rate, error-rate and duration
+// metrics. name is used as the "path" label instead of the raw request URL,
+// so callers should pass the route pattern the handler is registered under
+// (e.g. "/users/{id}") rather than r.URL.Path, which would otherwise blow
+// up cardinality on every distinct dynamic segment.
+//
+// When r carries a valid OpenTelemetry span context (see exemplarLabels),
+// the duration and count are recorded with a traceID/spanID exemplar so
+// Grafana can jump from a histogram bucket straight to the trace.
+func (m *Metrics) Wrap(name string, h http.Handler) http.Handler {
+    instrumented := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        m.requestSize.WithLabelValues(r.Method, name).Observe(float64(requestContentLength(r)))
+
+        rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+        timer := prometheus.NewTimer(prometheus.ObserverFunc(func(seconds float64) {
+            observeDuration(m.requestDuration.WithLabelValues(r.Method, name, strconv.Itoa(rec.status)), seconds, r)
+        }))
+        defer timer.ObserveDuration()
+
+        h.ServeHTTP(rec, r)
+
+        incCounter(m.requestsTotal.WithLabelValues(r.Method, name, strconv.Itoa(rec.status)), r)
+        m.responseSize.WithLabelValues(r.Method, name).Observe(float64(rec.size))
+    })
+
+    return promhttp.InstrumentHandlerInFlight(m.inFlightGauge, instrumented)
+}
+
+// Wrap instruments h with the package-level default Metrics set. See
+// Metrics.Wrap.
+func Wrap(name string, h http.Handler) http.Handler {
+    return defaultMetrics.Wrap(name, h)
+}
+
+// observeDuration records seconds on obs, attaching a trace exemplar from r
+// when one is available.
+func observeDuration(obs prometheus.Observer, seconds float64, r *http.Request) {
+    if labels, ok := exemplarLabels(r); ok {
+        if eo, ok := obs.(prometheus.ExemplarObserver); ok {
+            eo.ObserveWithExemplar(seconds, labels)
+            return
+        }
+    }
+    obs.Observe(seconds)
+}
+
+// incCounter increments c by one, attaching a trace exemplar from r when one
+// is available.
+func incCounter(c prometheus.Counter, r *http.Request) {
+    if labels, ok := exemplarLabels(r); ok {
+        if ea, ok := c.(prometheus.ExemplarAdder); ok {
+            ea.AddWithExemplar(1, labels)
+            return
+        }
+    }
+    c.Inc()
+}
+
+// requestContentLength returns the best-effort size of the request body.
+// r.ContentLength is -1 when the length is unknown (e.g. chunked transfer
+// encoding), in which case we report 0 rather than guessing.
+func requestContentLength(r *http.Request) int64 {
+    if r.ContentLength < 0 {
+        return 0
+    }
+    return r.ContentLength
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+    "net/http"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// exemplarLabels extracts a Prometheus exemplar label set ({traceID,
+// spanID}) from the OpenTelemetry span context attached to r's context, if
+// any. ok is false when the request carries no valid span context, e.g.
+// tracing is disabled or the request wasn't passed through otelhttp
+// middleware upstream of Wrap.
+//
+// A minimal tracer bootstrap that makes this work end to end, wiring
+// otelhttp ahead of Wrap so every request already carries a span by the
+// time it reaches us:
+//
+//	exp, _ := otlptracehttp.New(ctx)
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp))
+//	otel.SetTracerProvider(tp)
+//	defer tp.Shutdown(ctx)
+//
+//	http.Handle("/", otelhttp.NewHandler(metrics.Wrap("/", handler), "/"))
+//
+// Grafana's Exemplars panel then draws a marker on the
+// http_request_duration_seconds histogram for every sample with an
+// exemplar, linking straight back to the trace in Tempo/Jaeger.
+func exemplarLabels(r *http.Request) (prometheus.Labels, bool) {
+    sc := trace.SpanContextFromContext(r.Context())
+    if !sc.IsValid() {
+        return nil, false
+    }
+    return prometheus.Labels{
+        "traceID": sc.TraceID().String(),
+        "spanID":  sc.SpanID().String(),
+    }, true
+}
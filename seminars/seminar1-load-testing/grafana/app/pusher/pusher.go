@@ -0,0 +1,96 @@
+// Package pusher lets this binary run as a short-lived batch/cron job: instead
+// of exposing /metrics for a scraper to pull, it pushes its collectors to a
+// Pushgateway on exit or on a fixed interval.
+package pusher
+
+import (
+    "context"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Config controls how metrics are pushed to a Pushgateway.
+type Config struct {
+    // URL is the Pushgateway base address, e.g. "http://pushgateway:9091".
+    URL string
+    // Job is the "job" grouping label attached to every pushed metric.
+    Job string
+    // Instance is the "instance" grouping label, typically the hostname or
+    // pod name. Optional.
+    Instance string
+    // BasicAuthUser and BasicAuthPass enable HTTP basic auth against the
+    // Pushgateway when BasicAuthUser is set; BasicAuthPass may be empty.
+    BasicAuthUser string
+    BasicAuthPass string
+    // Interval, if non-zero, pushes on a ticker in addition to the final
+    // push Run performs when its context is cancelled. Zero means
+    // push-once-on-exit.
+    Interval time.Duration
+    // Replace selects Push (replace-then-add) semantics instead of the
+    // default Add (additive) semantics for every push.
+    Replace bool
+}
+
+// Pusher ships a Gatherer's collectors to a Pushgateway, either once or on a
+// fixed interval.
+type Pusher struct {
+    pusher   *push.Pusher
+    interval time.Duration
+    replace  bool
+}
+
+// New builds a Pusher that pushes everything registered on reg under
+// cfg.Job/cfg.Instance.
+func New(cfg Config, reg prometheus.Gatherer) *Pusher {
+    p := push.New(cfg.URL, cfg.Job).Gatherer(reg)
+    if cfg.Instance != "" {
+        p = p.Grouping("instance", cfg.Instance)
+    }
+    if cfg.BasicAuthUser != "" {
+        p = p.BasicAuth(cfg.BasicAuthUser, cfg.BasicAuthPass)
+    }
+
+    return &Pusher{
+        pusher:   p,
+        interval: cfg.Interval,
+        replace:  cfg.Replace,
+    }
+}
+
+// push performs a single push using Add or Push semantics, per cfg.Replace.
+func (p *Pusher) push() error {
+    if p.replace {
+        return p.pusher.Push()
+    }
+    return p.pusher.Add()
+}
+
+// Run pushes once immediately, then again on every Config.Interval tick,
+// until ctx is cancelled. Cancellation (e.g. on SIGTERM) always triggers one
+// final push before Run returns, so the last data point is never lost. If
+// Config.Interval is zero, Run pushes once and returns without waiting on
+// ctx at all.
+func (p *Pusher) Run(ctx context.Context) error {
+    if err := p.push(); err != nil {
+        return err
+    }
+    if p.interval <= 0 {
+        return nil
+    }
+
+    ticker := time.NewTicker(p.interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+            if err := p.push(); err != nil {
+                return err
+            }
+        case <-ctx.Done():
+            return p.push()
+        }
+    }
+}
@@ -0,0 +1,84 @@
+package pusher
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestServer(t *testing.T, onRequest func(*http.Request)) *httptest.Server {
+    t.Helper()
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        onRequest(r)
+        w.WriteHeader(http.StatusOK)
+    }))
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+func TestRunPushesOnceWhenIntervalIsZero(t *testing.T) {
+    var count int32
+    srv := newTestServer(t, func(*http.Request) { atomic.AddInt32(&count, 1) })
+
+    p := New(Config{URL: srv.URL, Job: "test"}, prometheus.NewRegistry())
+
+    if err := p.Run(context.Background()); err != nil {
+        t.Fatalf("Run: %v", err)
+    }
+    if got := atomic.LoadInt32(&count); got != 1 {
+        t.Errorf("pushes = %d, want 1", got)
+    }
+}
+
+func TestRunPushesOnIntervalUntilContextCancelled(t *testing.T) {
+    var count int32
+    srv := newTestServer(t, func(*http.Request) { atomic.AddInt32(&count, 1) })
+
+    p := New(Config{URL: srv.URL, Job: "test", Interval: 10 * time.Millisecond}, prometheus.NewRegistry())
+
+    ctx, cancel := context.WithTimeout(context.Background(), 45*time.Millisecond)
+    defer cancel()
+
+    if err := p.Run(ctx); err != nil {
+        t.Fatalf("Run: %v", err)
+    }
+    // One push on entry, several more on the 10ms ticker, then one final
+    // push on cancellation: comfortably more than the single push the
+    // zero-interval case produces.
+    if got := atomic.LoadInt32(&count); got < 3 {
+        t.Errorf("pushes = %d, want at least 3", got)
+    }
+}
+
+func TestRunUsesAddSemanticsByDefault(t *testing.T) {
+    var method string
+    srv := newTestServer(t, func(r *http.Request) { method = r.Method })
+
+    p := New(Config{URL: srv.URL, Job: "test"}, prometheus.NewRegistry())
+
+    if err := p.Run(context.Background()); err != nil {
+        t.Fatalf("Run: %v", err)
+    }
+    if method != http.MethodPost {
+        t.Errorf("method = %q, want POST (Add semantics)", method)
+    }
+}
+
+func TestRunUsesPushSemanticsWhenReplaceIsSet(t *testing.T) {
+    var method string
+    srv := newTestServer(t, func(r *http.Request) { method = r.Method })
+
+    p := New(Config{URL: srv.URL, Job: "test", Replace: true}, prometheus.NewRegistry())
+
+    if err := p.Run(context.Background()); err != nil {
+        t.Fatalf("Run: %v", err)
+    }
+    if method != http.MethodPut {
+        t.Errorf("method = %q, want PUT (Push semantics)", method)
+    }
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+    "crypto/tls"
+    "sync"
+    "time"
+)
+
+// certReloader lazily reloads a TLS keypair from disk, so a renewed
+// certificate is picked up without restarting the process. It only re-reads
+// the files once cacheFor has elapsed since the last successful load, and
+// falls back to the last good certificate if a reload attempt fails (e.g.
+// mid-rotation).
+type certReloader struct {
+    certFile, keyFile string
+    cacheFor          time.Duration
+
+    mu       sync.Mutex
+    cert     *tls.Certificate
+    loadedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, cacheFor time.Duration) *certReloader {
+    return &certReloader{certFile: certFile, keyFile: keyFile, cacheFor: cacheFor}
+}
+
+// GetCertificate is a tls.Config.GetCertificate callback.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.cert != nil && time.Since(r.loadedAt) < r.cacheFor {
+        return r.cert, nil
+    }
+
+    cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+    if err != nil {
+        if r.cert != nil {
+            return r.cert, nil
+        }
+        return nil, err
+    }
+
+    r.cert = &cert
+    r.loadedAt = time.Now()
+    return r.cert, nil
+}